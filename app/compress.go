@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// supportedEncodings lists the content codings this server can actually
+// produce, in the order negotiateEncoding tries them for a "*" wildcard
+// or when the client rules out identity. Among codings the client names
+// with equal q-values, the one named first in the header wins (e.g.
+// "Accept-Encoding: deflate, gzip" picks deflate).
+var supportedEncodings = []string{"gzip", "deflate"}
+
+// encoders builds a compress/*.Writer for each supported coding. Adding a
+// new coding (e.g. "br") means adding an entry here and to
+// supportedEncodings; negotiateEncoding and compressBody need no changes.
+var encoders = map[string]func(io.Writer) (io.WriteCloser, error){
+	"gzip": func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	},
+	"deflate": func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, flate.DefaultCompression)
+	},
+}
+
+// acceptEncoding is a single comma-separated entry of an Accept-Encoding
+// header, e.g. "gzip;q=0.8".
+type acceptEncoding struct {
+	coding string
+	q      float64
+}
+
+// parseAcceptEncoding splits an Accept-Encoding header into its weighted
+// codings. A coding with no explicit q-value defaults to q=1.0, matching
+// RFC 7231 section 5.3.1.
+func parseAcceptEncoding(header string) []acceptEncoding {
+	if header == "" {
+		return nil
+	}
+
+	var entries []acceptEncoding
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		coding, q := part, 1.0
+		if i := strings.Index(part, ";"); i != -1 {
+			coding = strings.TrimSpace(part[:i])
+			if qv, ok := strings.CutPrefix(strings.TrimSpace(part[i+1:]), "q="); ok {
+				if parsed, err := strconv.ParseFloat(qv, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		entries = append(entries, acceptEncoding{coding: strings.ToLower(coding), q: q})
+	}
+
+	return entries
+}
+
+// negotiateEncoding picks the best content coding this server supports
+// given a client's Accept-Encoding header. It returns "identity" if the
+// client sent no header or names no supported coding with a nonzero
+// q-value, unless it rules out identity with "identity;q=0": then the
+// first supported coding it hasn't also ruled out is returned, and
+// "identity" only when every supported coding is ruled out too.
+func negotiateEncoding(header string) string {
+	entries := parseAcceptEncoding(header)
+	if entries == nil {
+		return "identity"
+	}
+
+	best, bestQ := "identity", -1.0
+	sawWildcard := false
+	for _, e := range entries {
+		switch e.coding {
+		case "identity":
+			if e.q > bestQ {
+				best, bestQ = "identity", e.q
+			}
+		case "*":
+			sawWildcard = true
+			if e.q > 0 && e.q > bestQ {
+				for _, coding := range supportedEncodings {
+					if _, ok := encoders[coding]; ok {
+						best, bestQ = coding, e.q
+						break
+					}
+				}
+			}
+		default:
+			if _, ok := encoders[e.coding]; ok && e.q > bestQ {
+				best, bestQ = e.coding, e.q
+			}
+		}
+	}
+
+	if bestQ <= 0 && !sawWildcard {
+		// Nothing acceptable was named explicitly; identity is always
+		// allowed unless the client rules it out with "identity;q=0", in
+		// which case the first supported coding it hasn't also given q=0
+		// is used instead.
+		refused := make(map[string]bool)
+		for _, e := range entries {
+			if e.q == 0 {
+				refused[e.coding] = true
+			}
+		}
+		if refused["identity"] {
+			for _, coding := range supportedEncodings {
+				if !refused[coding] {
+					return coding
+				}
+			}
+		}
+		return "identity"
+	}
+
+	return best
+}
+
+// compressBody encodes body with the named coding. "identity" (or any
+// unrecognized coding) returns body unchanged.
+func compressBody(coding string, body []byte) ([]byte, error) {
+	newEncoder, ok := encoders[coding]
+	if !ok {
+		return body, nil
+	}
+
+	var buf bytes.Buffer
+	w, err := newEncoder(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
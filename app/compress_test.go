@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"no header", "", "identity"},
+		{"single coding, no q-value", "gzip", "gzip"},
+		{"highest q-value wins", "gzip;q=0.5, deflate;q=0.8", "deflate"},
+		{"unsupported coding is ignored", "br;q=1.0, gzip;q=0.1", "gzip"},
+		{"identity preferred when it has the highest q-value", "gzip;q=0.5, identity;q=0.8", "identity"},
+		{"identity;q=0 rules out the default, a supported coding is used instead", "identity;q=0", "gzip"},
+		{"wildcard picks the first supported coding", "*;q=1.0", "gzip"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := negotiateEncoding(tc.header); got != tc.want {
+				t.Errorf("negotiateEncoding(%q) = %q, want %q", tc.header, got, tc.want)
+			}
+		})
+	}
+}
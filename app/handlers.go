@@ -0,0 +1,136 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rosnerdev/go-webserver/app/internal/cgi"
+	"github.com/rosnerdev/go-webserver/app/internal/router"
+	"github.com/rosnerdev/go-webserver/app/internal/sniff"
+)
+
+// fileBaseDir is the directory /files serves from and writes into.
+const fileBaseDir = "/tmp/data/codecrafters.io/http-server-tester/"
+
+// cgiBinDir is the directory of executables mounted at /cgi-bin/.
+const cgiBinDir = "/tmp/data/codecrafters.io/http-server-tester/cgi-bin"
+
+// newMux builds the server's routing table: one handler per method and
+// path pattern, replacing the old hand-rolled path switch.
+func newMux() *router.Mux {
+	mux := router.NewMux()
+	mux.HandleFunc("GET", "/", handleRoot)
+	mux.HandleFunc("GET", "/echo", handleEchoEmpty)
+	mux.HandleFunc("GET", "/echo/{msg...}", handleEcho)
+	mux.HandleFunc("GET", "/user-agent", handleUserAgent)
+	mux.HandleFunc("GET", "/files", handleFilesEmpty)
+	mux.HandleFunc("GET", "/files/{name...}", handleFilesGet)
+	mux.HandleFunc("POST", "/files", handleFilesPostEmpty)
+	mux.HandleFunc("POST", "/files/{name...}", handleFilesPost)
+
+	cgiHandler := &cgi.Handler{
+		Dir:        cgiBinDir,
+		Prefix:     "/cgi-bin/",
+		Timeout:    10 * time.Second,
+		InheritEnv: []string{"PATH"},
+	}
+	mux.Handle("GET", "/cgi-bin/{script...}", cgiHandler)
+	mux.Handle("POST", "/cgi-bin/{script...}", cgiHandler)
+
+	return mux
+}
+
+func handleRoot(w *router.ResponseWriter, r *router.Request) {}
+
+func handleEchoEmpty(w *router.ResponseWriter, r *router.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+}
+
+func handleEcho(w *router.ResponseWriter, r *router.Request) {
+	writeTextResponse(w, r, "text/plain", []byte(r.PathValue("msg")))
+}
+
+func handleUserAgent(w *router.ResponseWriter, r *router.Request) {
+	writeTextResponse(w, r, "text/plain", []byte(r.Header.Get("User-Agent")))
+}
+
+// writeTextResponse negotiates a content coding from the request's
+// Accept-Encoding header, compresses body if one was chosen, and writes
+// the resulting Content-Type/Content-Encoding headers and body to w.
+func writeTextResponse(w *router.ResponseWriter, r *router.Request, contentType string, body []byte) {
+	coding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+
+	encoded, err := compressBody(coding, body)
+	if err != nil {
+		logger.Error(r.RemoteAddr, err)
+		coding, encoded = "identity", body
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if coding != "identity" {
+		w.Header().Set("Content-Encoding", coding)
+	}
+	w.Write(encoded)
+}
+
+func handleFilesEmpty(w *router.ResponseWriter, r *router.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+}
+
+// resolveFilePath joins name onto fileBaseDir after cleaning it, so a
+// name containing ".." can't escape fileBaseDir the way naive string
+// concatenation would.
+func resolveFilePath(name string) (string, bool) {
+	full := filepath.Join(fileBaseDir, filepath.Clean("/"+name))
+	if !strings.HasPrefix(full, filepath.Clean(fileBaseDir)+string(os.PathSeparator)) {
+		return "", false
+	}
+	return full, true
+}
+
+func handleFilesGet(w *router.ResponseWriter, r *router.Request) {
+	path, ok := resolveFilePath(r.PathValue("name"))
+	if !ok {
+		w.WriteHeader(router.StatusNotFound)
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		logger.Error(r.RemoteAddr, err)
+		w.WriteHeader(router.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	fileContent, err := io.ReadAll(file)
+	if err != nil {
+		logger.Error(r.RemoteAddr, err)
+		w.WriteHeader(router.StatusInternalServerError)
+		return
+	}
+
+	writeTextResponse(w, r, sniff.DetectContentType(fileContent), fileContent)
+}
+
+func handleFilesPostEmpty(w *router.ResponseWriter, r *router.Request) {
+	w.WriteHeader(router.StatusBadRequest)
+}
+
+func handleFilesPost(w *router.ResponseWriter, r *router.Request) {
+	path, ok := resolveFilePath(r.PathValue("name"))
+	if !ok {
+		w.WriteHeader(router.StatusBadRequest)
+		return
+	}
+
+	if err := os.WriteFile(path, r.Body, 0644); err != nil {
+		logger.Error(r.RemoteAddr, err)
+		w.WriteHeader(router.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(router.StatusCreated)
+}
@@ -0,0 +1,35 @@
+// Package accesslog defines a pluggable interface for recording a
+// request's lifecycle, with Common Log Format and JSON implementations.
+package accesslog
+
+import "time"
+
+// Entry describes one completed request, with enough detail to log it
+// accurately even after the response was compressed or otherwise
+// transformed on its way out.
+type Entry struct {
+	Timestamp  time.Time
+	RemoteAddr string
+	Method     string
+	Path       string
+	Proto      string
+	Status     int
+	Bytes      int
+	Duration   time.Duration
+}
+
+// Logger receives access-log events. Implementations must be safe for
+// concurrent use, since handleConnection runs one per connection.
+type Logger interface {
+	// RequestStarted is called as soon as a request's line and headers
+	// have been read, before its handler runs.
+	RequestStarted(remoteAddr, method, path string)
+
+	// RequestCompleted is called once the response has been fully
+	// rendered, with the real status code, byte count, and duration.
+	RequestCompleted(entry Entry)
+
+	// Error reports a connection- or request-level failure not tied to
+	// a specific completed request, e.g. a read/write error.
+	Error(remoteAddr string, err error)
+}
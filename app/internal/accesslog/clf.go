@@ -0,0 +1,49 @@
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// clfTimeFormat is the Apache/NCSA date format used by Common Log
+// Format entries, e.g. "10/Oct/2000:13:55:36 -0700".
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// CommonLogFormat writes completed requests in the Apache/NCSA Common
+// Log Format. RequestStarted is a no-op, since CLF has no room for a
+// start event; Error writes a line with a "-" request and status 0.
+// Writes are serialized with mu, since Logger implementations must be
+// safe for concurrent use and Out may not be atomic on its own (unlike
+// os.Stdout, a bufio.Writer isn't).
+type CommonLogFormat struct {
+	Out io.Writer
+
+	mu sync.Mutex
+}
+
+func (c *CommonLogFormat) RequestStarted(remoteAddr, method, path string) {}
+
+func (c *CommonLogFormat) RequestCompleted(e Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.Out, "%s - - [%s] \"%s %s %s\" %d %d\n",
+		clfHost(e.RemoteAddr), e.Timestamp.Format(clfTimeFormat),
+		e.Method, e.Path, e.Proto, e.Status, e.Bytes)
+}
+
+func (c *CommonLogFormat) Error(remoteAddr string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.Out, "%s - - [%s] \"-\" 0 0 %q\n",
+		clfHost(remoteAddr), time.Now().Format(clfTimeFormat), err.Error())
+}
+
+func clfHost(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
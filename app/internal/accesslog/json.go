@@ -0,0 +1,77 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// JSON writes one JSON object per line (JSONL), suitable for shipping to
+// Loki, ELK, or any other log pipeline that ingests structured logs.
+// Writes are serialized with mu, since Logger implementations must be
+// safe for concurrent use and Out may not be atomic on its own (unlike
+// os.Stdout, a bufio.Writer isn't).
+type JSON struct {
+	Out io.Writer
+
+	mu sync.Mutex
+}
+
+type jsonEvent struct {
+	Event      string  `json:"event"`
+	Timestamp  string  `json:"timestamp"`
+	RemoteAddr string  `json:"remote_addr"`
+	Method     string  `json:"method,omitempty"`
+	Path       string  `json:"path,omitempty"`
+	Proto      string  `json:"proto,omitempty"`
+	Status     int     `json:"status,omitempty"`
+	Bytes      int     `json:"bytes,omitempty"`
+	DurationMS float64 `json:"duration_ms,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+func (j *JSON) RequestStarted(remoteAddr, method, path string) {
+	j.write(jsonEvent{
+		Event:      "request_started",
+		Timestamp:  time.Now().Format(time.RFC3339Nano),
+		RemoteAddr: remoteAddr,
+		Method:     method,
+		Path:       path,
+	})
+}
+
+func (j *JSON) RequestCompleted(e Entry) {
+	j.write(jsonEvent{
+		Event:      "request_completed",
+		Timestamp:  e.Timestamp.Format(time.RFC3339Nano),
+		RemoteAddr: e.RemoteAddr,
+		Method:     e.Method,
+		Path:       e.Path,
+		Proto:      e.Proto,
+		Status:     e.Status,
+		Bytes:      e.Bytes,
+		DurationMS: float64(e.Duration.Microseconds()) / 1000,
+	})
+}
+
+func (j *JSON) Error(remoteAddr string, err error) {
+	j.write(jsonEvent{
+		Event:      "error",
+		Timestamp:  time.Now().Format(time.RFC3339Nano),
+		RemoteAddr: remoteAddr,
+		Error:      err.Error(),
+	})
+}
+
+func (j *JSON) write(e jsonEvent) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Out.Write(data)
+}
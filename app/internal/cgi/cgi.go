@@ -0,0 +1,203 @@
+// Package cgi hosts external scripts as RFC 3875 CGI/1.1 programs,
+// modeled on the child/host split in net/http/cgi: the host sets up the
+// request environment and pipes, the child is any executable that
+// speaks the CGI protocol on stdin/stdout.
+package cgi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/textproto"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rosnerdev/go-webserver/app/internal/router"
+)
+
+// Handler executes executables found in Dir as CGI programs, mounted at
+// the URL path Prefix (e.g. "/cgi-bin/"). It implements router.Handler,
+// so it's registered on a Mux the same way any other handler is; the
+// route pattern supplies the rest of the path via PathValue.
+type Handler struct {
+	// Dir is the directory scripts are resolved from. The first path
+	// segment after Prefix names the script; anything after that becomes
+	// PATH_INFO.
+	Dir string
+
+	// Prefix is the URL path this handler is mounted at, used to build
+	// SCRIPT_NAME. It should match the pattern the handler is registered
+	// under, e.g. both "/cgi-bin/" and "/cgi-bin/{script...}".
+	Prefix string
+
+	// WorkingDir is the child process's working directory. Dir is used
+	// if this is empty.
+	WorkingDir string
+
+	// Timeout bounds how long a script may run. Zero means no limit.
+	Timeout time.Duration
+
+	// InheritEnv lists names of the host's own environment variables to
+	// pass through to the child, e.g. "PATH". Nothing is inherited by
+	// default: CGI scripts get the RFC 3875 variables plus Env below.
+	InheritEnv []string
+
+	// Env is a fixed set of extra "key=value" pairs passed to every
+	// script, e.g. "SERVER_SOFTWARE=go-webserver".
+	Env []string
+
+	// PathValue names the route's wildcard pattern parameter holding the
+	// part of the path after Prefix, e.g. "script". Defaults to "script".
+	PathValue string
+}
+
+// ServeHTTP resolves the script named by the request path, runs it with
+// the RFC 3875 environment and the request body on stdin, and translates
+// its CGI response (a header block followed by a blank line and the
+// body) into an HTTP response.
+func (h *Handler) ServeHTTP(w *router.ResponseWriter, r *router.Request) {
+	paramName := h.PathValue
+	if paramName == "" {
+		paramName = "script"
+	}
+
+	scriptName, pathInfo, ok := h.resolveScript(r.PathValue(paramName))
+	if !ok {
+		w.WriteHeader(router.StatusNotFound)
+		return
+	}
+
+	ctx := context.Background()
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	workDir := h.WorkingDir
+	if workDir == "" {
+		workDir = h.Dir
+	}
+
+	cmd := exec.CommandContext(ctx, filepath.Join(h.Dir, scriptName))
+	cmd.Dir = workDir
+	cmd.Env = h.env(r, scriptName, pathInfo)
+	cmd.Stdin = bytes.NewReader(r.Body)
+	cmd.Stderr = os.Stderr
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			w.WriteHeader(router.StatusGatewayTimeout)
+		} else {
+			w.WriteHeader(router.StatusBadGateway)
+		}
+		return
+	}
+
+	writeCGIResponse(w, stdout.Bytes())
+}
+
+// resolveScript splits the part of the path after Prefix into the
+// script's filename and its PATH_INFO, and confirms the script exists,
+// isn't a directory, and isn't reached via a "..".
+func (h *Handler) resolveScript(rest string) (scriptName, pathInfo string, ok bool) {
+	rest = strings.TrimPrefix(rest, "/")
+	if rest == "" {
+		return "", "", false
+	}
+
+	scriptName, pathInfo, _ = strings.Cut(rest, "/")
+	if pathInfo != "" {
+		pathInfo = "/" + pathInfo
+	}
+	if scriptName == "" || strings.Contains(scriptName, "..") {
+		return "", "", false
+	}
+
+	info, err := os.Stat(filepath.Join(h.Dir, scriptName))
+	if err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+		return "", "", false
+	}
+
+	return scriptName, pathInfo, true
+}
+
+// env builds the RFC 3875 CGI environment for one request: the required
+// metavariables, CONTENT_LENGTH/CONTENT_TYPE when applicable, an
+// HTTP_<NAME> variable per request header, and the host's allowlisted
+// and fixed variables.
+func (h *Handler) env(r *router.Request, scriptName, pathInfo string) []string {
+	env := []string{
+		"GATEWAY_INTERFACE=CGI/1.1",
+		"SERVER_PROTOCOL=HTTP/1.1",
+		"REQUEST_METHOD=" + r.Method,
+		"SCRIPT_NAME=" + h.Prefix + scriptName,
+		"PATH_INFO=" + pathInfo,
+		"QUERY_STRING=" + r.RawQuery,
+	}
+
+	if cl := r.Header.Get("Content-Length"); cl != "" {
+		env = append(env, "CONTENT_LENGTH="+cl)
+	} else if len(r.Body) > 0 {
+		env = append(env, "CONTENT_LENGTH="+strconv.Itoa(len(r.Body)))
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		env = append(env, "CONTENT_TYPE="+ct)
+	}
+
+	for key, values := range r.Header {
+		if key == "Content-Length" || key == "Content-Type" {
+			continue
+		}
+		name := "HTTP_" + strings.ReplaceAll(strings.ToUpper(key), "-", "_")
+		env = append(env, name+"="+strings.Join(values, ", "))
+	}
+
+	for _, name := range h.InheritEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	return append(env, h.Env...)
+}
+
+// writeCGIResponse parses output as a CGI response: a MIME-style header
+// block, a blank line, then the body. A "Status:" header sets the HTTP
+// status line; every other header is copied through as-is.
+func writeCGIResponse(w *router.ResponseWriter, output []byte) {
+	tp := textproto.NewReader(bufio.NewReader(bytes.NewReader(output)))
+
+	header, err := tp.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		w.WriteHeader(router.StatusBadGateway)
+		return
+	}
+
+	status := router.StatusOK
+	if line := header.Get("Status"); line != "" {
+		code, _, _ := strings.Cut(line, " ")
+		if n, err := strconv.Atoi(code); err == nil {
+			status = n
+		}
+		header.Del("Status")
+	}
+
+	for key, values := range header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(status)
+
+	body, _ := io.ReadAll(tp.R)
+	w.Write(body)
+}
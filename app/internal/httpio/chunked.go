@@ -0,0 +1,105 @@
+package httpio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// chunkedReader decodes an RFC 7230 section 4.1 chunked transfer coding:
+// a sequence of "<hex-size>[;ext]\r\n<data>\r\n" chunks terminated by a
+// zero-size chunk, optional trailer headers, and a final CRLF.
+type chunkedReader struct {
+	r    *bufio.Reader
+	n    int64 // bytes remaining in the chunk currently being read
+	done bool
+	err  error
+}
+
+func newChunkedReader(r *bufio.Reader) *chunkedReader {
+	return &chunkedReader{r: r}
+}
+
+func (cr *chunkedReader) Read(p []byte) (int, error) {
+	if cr.err != nil {
+		return 0, cr.err
+	}
+
+	if cr.n == 0 && !cr.done {
+		if err := cr.beginChunk(); err != nil {
+			cr.err = err
+			return 0, err
+		}
+	}
+	if cr.done {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > cr.n {
+		p = p[:cr.n]
+	}
+	n, err := cr.r.Read(p)
+	cr.n -= int64(n)
+	if err != nil {
+		cr.err = fmt.Errorf("httpio: reading chunk data: %w", err)
+		return n, cr.err
+	}
+
+	if cr.n == 0 {
+		if err := discardCRLF(cr.r); err != nil {
+			cr.err = err
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// beginChunk reads one chunk-size line and, on a terminating zero-size
+// chunk, the trailer section that follows it.
+func (cr *chunkedReader) beginChunk() error {
+	line, err := cr.r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("httpio: reading chunk size: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if i := strings.IndexByte(line, ';'); i >= 0 {
+		line = line[:i] // chunk extensions are accepted but ignored
+	}
+
+	size, err := strconv.ParseInt(strings.TrimSpace(line), 16, 64)
+	if err != nil {
+		return fmt.Errorf("httpio: malformed chunk size %q: %w", line, err)
+	}
+
+	if size == 0 {
+		cr.done = true
+		return cr.readTrailer()
+	}
+
+	cr.n = size
+	return nil
+}
+
+func (cr *chunkedReader) readTrailer() error {
+	tp := textproto.NewReader(cr.r)
+	if _, err := tp.ReadMIMEHeader(); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("httpio: reading chunk trailer: %w", err)
+	}
+	return nil
+}
+
+func discardCRLF(r *bufio.Reader) error {
+	var buf [2]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return fmt.Errorf("httpio: reading chunk terminator: %w", err)
+	}
+	if buf[0] != '\r' || buf[1] != '\n' {
+		return errors.New("httpio: malformed chunk terminator")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package httpio
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestChunkedReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "single chunk",
+			raw:  "5\r\nhello\r\n0\r\n\r\n",
+			want: "hello",
+		},
+		{
+			name: "multiple chunks",
+			raw:  "4\r\nWiki\r\n5\r\npedia\r\n0\r\n\r\n",
+			want: "Wikipedia",
+		},
+		{
+			name: "chunk extension is ignored",
+			raw:  "5;foo=bar\r\nhello\r\n0\r\n\r\n",
+			want: "hello",
+		},
+		{
+			name: "trailer after final chunk",
+			raw:  "5\r\nhello\r\n0\r\nX-Trailer: yes\r\n\r\n",
+			want: "hello",
+		},
+		{
+			name:    "malformed chunk size",
+			raw:     "zz\r\nhello\r\n0\r\n\r\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing chunk terminator",
+			raw:     "5\r\nhelloXX0\r\n\r\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cr := newChunkedReader(bufio.NewReader(strings.NewReader(tc.raw)))
+
+			got, err := io.ReadAll(cr)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("ReadAll(%q) = nil error, want error", tc.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadAll(%q) returned error: %v", tc.raw, err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("body = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Package httpio implements a minimal RFC 7230 HTTP/1.x message reader,
+// modeled on net/textproto and net/http/internal. Unlike a single
+// fixed-struct parse of one request, it supports persistent connections,
+// chunked transfer encoding, and request pipelining: callers loop over
+// ReadRequest against the same *bufio.Reader until it returns io.EOF or
+// a request whose Close field is set.
+package httpio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Request is a parsed HTTP/1.x request line, header block, and body.
+type Request struct {
+	Method string
+	Path   string
+	Proto  string
+	Header map[string][]string
+	Body   io.ReadCloser
+
+	// Close reports whether the connection should be closed once this
+	// request's response has been written, per the Connection header and
+	// the HTTP/1.x default persistence rules.
+	Close bool
+}
+
+// Get returns the first value associated with the canonical form of key,
+// or "" if the header was not sent.
+func (r *Request) Get(key string) string {
+	values := r.Header[textproto.CanonicalMIMEHeaderKey(key)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// ReadRequest reads a single HTTP/1.x request from r: the request line,
+// a MIME-style header block, and, if present, a body framed by either
+// Content-Length or chunked Transfer-Encoding. It returns io.EOF if the
+// connection closed cleanly before a request line arrived, which is the
+// expected way a persistent connection ends.
+func ReadRequest(r *bufio.Reader) (*Request, error) {
+	tp := textproto.NewReader(r)
+
+	line, err := tp.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+
+	method, path, proto, err := parseRequestLine(line)
+	if err != nil {
+		return nil, err
+	}
+
+	mimeHeader, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("httpio: reading headers: %w", err)
+	}
+	header := map[string][]string(mimeHeader)
+
+	body, err := requestBody(r, header)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &Request{Method: method, Path: path, Proto: proto, Header: header}
+	req.Close = shouldClose(proto, header)
+	req.Body = body
+
+	return req, nil
+}
+
+func parseRequestLine(line string) (method, path, proto string, err error) {
+	parts := strings.SplitN(line, " ", 3)
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("httpio: malformed request line %q", line)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+func shouldClose(proto string, header map[string][]string) bool {
+	connection := strings.ToLower(strings.Join(header["Connection"], ","))
+	switch {
+	case strings.Contains(connection, "close"):
+		return true
+	case strings.Contains(connection, "keep-alive"):
+		return false
+	default:
+		// HTTP/1.1 connections are persistent by default; HTTP/1.0 ones
+		// close unless Keep-Alive was explicitly negotiated above.
+		return proto != "HTTP/1.1"
+	}
+}
+
+// requestBody determines how the body is framed and returns a reader
+// bounded to exactly that many bytes. A Content-Length that doesn't
+// parse, or is sent more than once, is rejected rather than treated as
+// "no body": silently guessing would leave unread bytes sitting in r,
+// and the next ReadRequest on this connection would read straight into
+// them as if they were the next request's start line.
+func requestBody(r *bufio.Reader, header map[string][]string) (io.ReadCloser, error) {
+	for _, coding := range header["Transfer-Encoding"] {
+		if strings.EqualFold(strings.TrimSpace(coding), "chunked") {
+			return io.NopCloser(newChunkedReader(r)), nil
+		}
+	}
+
+	cl := header["Content-Length"]
+	if len(cl) == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	if len(cl) > 1 {
+		return nil, fmt.Errorf("httpio: conflicting Content-Length headers %q", cl)
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(cl[0]), 10, 64)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("httpio: malformed Content-Length %q", cl[0])
+	}
+
+	return io.NopCloser(io.LimitReader(r, n)), nil
+}
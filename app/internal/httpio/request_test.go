@@ -0,0 +1,87 @@
+package httpio
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		wantErr  bool
+		wantBody string
+		wantMore bool // a second request follows on the same connection
+	}{
+		{
+			name:     "no body",
+			raw:      "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n",
+			wantBody: "",
+		},
+		{
+			name:     "content-length",
+			raw:      "POST /echo HTTP/1.1\r\nContent-Length: 5\r\n\r\nhello",
+			wantBody: "hello",
+		},
+		{
+			name: "chunked",
+			raw: "POST /echo HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n" +
+				"5\r\nhello\r\n0\r\n\r\n",
+			wantBody: "hello",
+		},
+		{
+			name:    "malformed content-length",
+			raw:     "POST /echo HTTP/1.1\r\nContent-Length: 12x\r\n\r\nhello",
+			wantErr: true,
+		},
+		{
+			name: "duplicate content-length",
+			raw: "POST /echo HTTP/1.1\r\nContent-Length: 5\r\nContent-Length: 5\r\n\r\n" +
+				"hello",
+			wantErr: true,
+		},
+		{
+			name:     "pipelined requests leave the next request line intact",
+			raw:      "POST /a HTTP/1.1\r\nContent-Length: 5\r\n\r\nhelloGET /b HTTP/1.1\r\n\r\n",
+			wantBody: "hello",
+			wantMore: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tc.raw))
+
+			req, err := ReadRequest(r)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("ReadRequest(%q) = nil error, want error", tc.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadRequest(%q) returned error: %v", tc.raw, err)
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatalf("reading body: %v", err)
+			}
+			if string(body) != tc.wantBody {
+				t.Errorf("body = %q, want %q", body, tc.wantBody)
+			}
+
+			if tc.wantMore {
+				next, err := ReadRequest(r)
+				if err != nil {
+					t.Fatalf("ReadRequest of pipelined request returned error: %v", err)
+				}
+				if next.Method != "GET" || next.Path != "/b" {
+					t.Errorf("pipelined request = %s %s, want GET /b", next.Method, next.Path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+// Package metrics exposes the server's semaphore/backpressure counters
+// via expvar. Importing it registers expvar's handler on
+// http.DefaultServeMux at "/debug/vars"; mount that on a separate admin
+// listener rather than the main server, and scrape it directly or with
+// a Prometheus expvar exporter in front.
+package metrics
+
+import "expvar"
+
+var (
+	// ActiveConnections is the number of connections currently holding a
+	// worker semaphore slot.
+	ActiveConnections = expvar.NewInt("active_connections")
+
+	// QueuedConnections is the number of accepted connections waiting
+	// for a semaphore slot to free up.
+	QueuedConnections = expvar.NewInt("queued_connections")
+)
@@ -0,0 +1,28 @@
+package router
+
+import "net/textproto"
+
+// Header is a canonicalized set of HTTP header values, keyed the same
+// way net/http.Header and httpio.Request.Header are.
+type Header map[string][]string
+
+// Get returns the first value associated with the canonical form of
+// key, or "" if it was not set.
+func (h Header) Get(key string) string {
+	values := h[textproto.CanonicalMIMEHeaderKey(key)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set replaces any existing values for key with value.
+func (h Header) Set(key, value string) {
+	h[textproto.CanonicalMIMEHeaderKey(key)] = []string{value}
+}
+
+// Add appends value to any existing values for key.
+func (h Header) Add(key, value string) {
+	k := textproto.CanonicalMIMEHeaderKey(key)
+	h[k] = append(h[k], value)
+}
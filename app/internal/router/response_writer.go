@@ -0,0 +1,79 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// ResponseWriter buffers a handler's status code, headers, and body so
+// they can be written to the connection exactly once, with an accurate
+// Content-Length computed from what was actually written rather than
+// hand-formatted by the handler.
+type ResponseWriter struct {
+	status int
+	header Header
+	body   bytes.Buffer
+}
+
+// NewResponseWriter returns a ResponseWriter defaulted to 200 OK, as
+// net/http's ResponseWriter does when a handler never calls WriteHeader.
+func NewResponseWriter() *ResponseWriter {
+	return &ResponseWriter{status: StatusOK, header: make(Header)}
+}
+
+// Header returns the header map to be sent, which handlers may mutate
+// before the response is rendered.
+func (w *ResponseWriter) Header() Header {
+	return w.header
+}
+
+// WriteHeader sets the status code for the response. Handlers that never
+// call it get the default of 200 OK.
+func (w *ResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+// Write appends p to the response body.
+func (w *ResponseWriter) Write(p []byte) (int, error) {
+	return w.body.Write(p)
+}
+
+// StatusCode returns the status code set by the handler, or the 200
+// default if it never called WriteHeader.
+func (w *ResponseWriter) StatusCode() int {
+	return w.status
+}
+
+// Len returns the number of bytes written to the body so far.
+func (w *ResponseWriter) Len() int {
+	return w.body.Len()
+}
+
+// Render serializes w as a complete HTTP/1.1 response: the status line,
+// headers in a stable order, a blank line, and the body. Content-Length
+// is always set from the buffered body, overriding anything a handler
+// set directly.
+func Render(w *ResponseWriter) []byte {
+	w.header.Set("Content-Length", strconv.Itoa(w.body.Len()))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "HTTP/1.1 %d %s\r\n", w.status, StatusText(w.status))
+
+	keys := make([]string, 0, len(w.header))
+	for key := range w.header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range w.header[key] {
+			fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+		}
+	}
+
+	buf.WriteString("\r\n")
+	buf.Write(w.body.Bytes())
+	return buf.Bytes()
+}
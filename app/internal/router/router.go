@@ -0,0 +1,153 @@
+// Package router provides a small ServeMux/Handler abstraction in the
+// style of net/http: handlers implement Handler, a Mux dispatches to
+// them by method and path pattern, and path segments like "{name}" (one
+// segment) or "{name...}" (the rest of the path) are exposed to the
+// handler via Request.PathValue.
+package router
+
+import "strings"
+
+// Request is one HTTP request as seen by a Handler: method, path,
+// headers, and a fully-read body, plus any path parameters the Mux
+// extracted while matching the route.
+type Request struct {
+	Method     string
+	Path       string
+	RawQuery   string
+	Header     Header
+	Body       []byte
+	RemoteAddr string
+
+	pathValues map[string]string
+}
+
+// PathValue returns the value matched for a "{name}" or "{name...}"
+// segment in the route pattern, or "" if name wasn't part of the match.
+func (r *Request) PathValue(name string) string {
+	return r.pathValues[name]
+}
+
+// Handler responds to an HTTP request by writing to w.
+type Handler interface {
+	ServeHTTP(w *ResponseWriter, r *Request)
+}
+
+// HandlerFunc adapts a plain function to Handler.
+type HandlerFunc func(w *ResponseWriter, r *Request)
+
+func (f HandlerFunc) ServeHTTP(w *ResponseWriter, r *Request) {
+	f(w, r)
+}
+
+// Mux dispatches requests to registered handlers by method and path
+// pattern, trying routes in registration order and using the first one
+// that matches both.
+type Mux struct {
+	routes []route
+}
+
+type route struct {
+	method   string
+	segments []segment
+	handler  Handler
+}
+
+// segment is one "/"-separated piece of a route pattern: either a fixed
+// literal, a single-segment parameter ("{name}"), or a rest-of-path
+// parameter ("{name...}") which must be the pattern's final segment.
+type segment struct {
+	literal  string
+	param    string
+	wildcard bool
+}
+
+// NewMux returns an empty Mux.
+func NewMux() *Mux {
+	return &Mux{}
+}
+
+// Handle registers handler for requests matching method and pattern.
+func (m *Mux) Handle(method, pattern string, handler Handler) {
+	m.routes = append(m.routes, route{
+		method:   method,
+		segments: parsePattern(pattern),
+		handler:  handler,
+	})
+}
+
+// HandleFunc registers handler for requests matching method and pattern.
+func (m *Mux) HandleFunc(method, pattern string, handler func(w *ResponseWriter, r *Request)) {
+	m.Handle(method, pattern, HandlerFunc(handler))
+}
+
+// ServeHTTP finds the first route whose method and pattern match r and
+// invokes its handler, populating r's path values along the way. If no
+// route matches, it writes a 404.
+func (m *Mux) ServeHTTP(w *ResponseWriter, r *Request) {
+	for _, rt := range m.routes {
+		if rt.method != r.Method {
+			continue
+		}
+		values, ok := match(rt.segments, r.Path)
+		if !ok {
+			continue
+		}
+		r.pathValues = values
+		rt.handler.ServeHTTP(w, r)
+		return
+	}
+	w.WriteHeader(StatusNotFound)
+}
+
+func parsePattern(pattern string) []segment {
+	trimmed := strings.Trim(pattern, "/")
+	if trimmed == "" {
+		return nil
+	}
+
+	parts := strings.Split(trimmed, "/")
+	segments := make([]segment, len(parts))
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			name := part[1 : len(part)-1]
+			wildcard := strings.HasSuffix(name, "...")
+			if wildcard {
+				name = strings.TrimSuffix(name, "...")
+			}
+			segments[i] = segment{param: name, wildcard: wildcard}
+			continue
+		}
+		segments[i] = segment{literal: part}
+	}
+	return segments
+}
+
+func match(segments []segment, path string) (map[string]string, bool) {
+	var pathSegments []string
+	if trimmed := strings.Trim(path, "/"); trimmed != "" {
+		pathSegments = strings.Split(trimmed, "/")
+	}
+
+	values := make(map[string]string)
+	for i, seg := range segments {
+		if seg.wildcard {
+			values[seg.param] = strings.Join(pathSegments[min(i, len(pathSegments)):], "/")
+			return values, true
+		}
+		if i >= len(pathSegments) {
+			return nil, false
+		}
+		if seg.param != "" {
+			values[seg.param] = pathSegments[i]
+			continue
+		}
+		if seg.literal != pathSegments[i] {
+			return nil, false
+		}
+	}
+
+	if len(pathSegments) != len(segments) {
+		return nil, false
+	}
+	return values, true
+}
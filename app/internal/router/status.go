@@ -0,0 +1,32 @@
+package router
+
+// HTTP status codes used by the handlers in this server. Unlike
+// net/http's exhaustive table, this only covers the responses the
+// handlers actually produce.
+const (
+	StatusOK                  = 200
+	StatusCreated             = 201
+	StatusBadRequest          = 400
+	StatusNotFound            = 404
+	StatusMethodNotAllowed    = 405
+	StatusInternalServerError = 500
+	StatusBadGateway          = 502
+	StatusGatewayTimeout      = 504
+)
+
+var statusText = map[int]string{
+	StatusOK:                  "OK",
+	StatusCreated:             "Created",
+	StatusBadRequest:          "Bad Request",
+	StatusNotFound:            "Not Found",
+	StatusMethodNotAllowed:    "Method Not Allowed",
+	StatusInternalServerError: "Internal Server Error",
+	StatusBadGateway:          "Bad Gateway",
+	StatusGatewayTimeout:      "Gateway Timeout",
+}
+
+// StatusText returns the reason phrase for code, or "" if it's not one
+// of the statuses this server knows how to produce.
+func StatusText(code int) string {
+	return statusText[code]
+}
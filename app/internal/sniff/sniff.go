@@ -0,0 +1,188 @@
+// Package sniff identifies the MIME type of a byte payload by its
+// content rather than trusting a filename extension, following the
+// signature-matching portion of the WHATWG MIME Sniffing Standard (the
+// same rules net/http.DetectContentType implements).
+package sniff
+
+import "bytes"
+
+// sniffLen is the number of leading bytes inspected, per the standard.
+const sniffLen = 512
+
+// DetectContentType examines up to the first 512 bytes of data against
+// a table of known signatures (HTML tag prefixes, XML, PDF, PostScript,
+// UTF byte-order marks, and common image/audio/video/archive magic
+// numbers) and returns the matching MIME type. If nothing matches, it
+// returns "text/plain; charset=utf-8" when the bytes look textual and
+// "application/octet-stream" otherwise.
+func DetectContentType(data []byte) string {
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+
+	for _, sig := range signatures {
+		if sig.matches(data) {
+			return sig.mime
+		}
+	}
+
+	if looksTextual(data) {
+		return "text/plain; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+type signature struct {
+	mime    string
+	matches func(data []byte) bool
+}
+
+var signatures = []signature{
+	{"text/html; charset=utf-8", htmlSignature("<!DOCTYPE HTML")},
+	{"text/html; charset=utf-8", htmlSignature("<HTML")},
+	{"text/html; charset=utf-8", htmlSignature("<HEAD")},
+	{"text/html; charset=utf-8", htmlSignature("<SCRIPT")},
+	{"text/html; charset=utf-8", htmlSignature("<IFRAME")},
+	{"text/html; charset=utf-8", htmlSignature("<H1")},
+	{"text/html; charset=utf-8", htmlSignature("<DIV")},
+	{"text/html; charset=utf-8", htmlSignature("<FONT")},
+	{"text/html; charset=utf-8", htmlSignature("<TABLE")},
+	{"text/html; charset=utf-8", htmlSignature("<A")},
+	{"text/html; charset=utf-8", htmlSignature("<STYLE")},
+	{"text/html; charset=utf-8", htmlSignature("<TITLE")},
+	{"text/html; charset=utf-8", htmlSignature("<B")},
+	{"text/html; charset=utf-8", htmlSignature("<BODY")},
+	{"text/html; charset=utf-8", htmlSignature("<BR")},
+	{"text/html; charset=utf-8", htmlSignature("<P")},
+
+	{"text/xml; charset=utf-8", prefixSignature("<?xml")},
+	{"application/pdf", prefixSignature("%PDF-")},
+	{"application/postscript", prefixSignature("%!PS-Adobe-")},
+
+	{"text/plain; charset=utf-16be", prefixSignature("\xfe\xff")},
+	{"text/plain; charset=utf-16le", prefixSignature("\xff\xfe")},
+	{"text/plain; charset=utf-8", prefixSignature("\xef\xbb\xbf")},
+
+	{"image/png", prefixSignature("\x89PNG\r\n\x1a\n")},
+	{"image/gif", prefixSignature("GIF87a")},
+	{"image/gif", prefixSignature("GIF89a")},
+	{"image/webp", maskedSignature(
+		[]byte("RIFF\x00\x00\x00\x00WEBPVP"),
+		[]byte("\xff\xff\xff\xff\x00\x00\x00\x00\xff\xff\xff\xff\xff\xff"),
+	)},
+	{"image/jpeg", prefixSignature("\xff\xd8\xff")},
+
+	{"audio/mpeg", prefixSignature("ID3")},
+	{"application/ogg", prefixSignature("OggS\x00")},
+	{"audio/wave", maskedSignature(
+		[]byte("RIFF\x00\x00\x00\x00WAVE"),
+		[]byte("\xff\xff\xff\xff\x00\x00\x00\x00\xff\xff\xff\xff"),
+	)},
+	{"video/avi", maskedSignature(
+		[]byte("RIFF\x00\x00\x00\x00AVI "),
+		[]byte("\xff\xff\xff\xff\x00\x00\x00\x00\xff\xff\xff\xff"),
+	)},
+	{"video/mp4", matchesMP4},
+
+	{"application/zip", prefixSignature("PK\x03\x04")},
+	{"application/x-gzip", prefixSignature("\x1f\x8b\x08")},
+	{"application/x-rar-compressed", prefixSignature("Rar \x1a\x07\x00")},
+}
+
+// prefixSignature matches payloads that start with an exact byte
+// sequence, e.g. a magic number or byte-order mark.
+func prefixSignature(prefix string) func([]byte) bool {
+	p := []byte(prefix)
+	return func(data []byte) bool {
+		return bytes.HasPrefix(data, p)
+	}
+}
+
+// maskedSignature matches payloads whose prefix equals pattern once
+// each byte has been ANDed with the corresponding mask byte, so a
+// signature can ignore bytes it doesn't care about (e.g. a RIFF
+// container's 4-byte size field).
+func maskedSignature(pattern, mask []byte) func([]byte) bool {
+	return func(data []byte) bool {
+		if len(data) < len(pattern) {
+			return false
+		}
+		for i, want := range pattern {
+			if data[i]&mask[i] != want {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// htmlSignature matches an HTML tag prefix, case-insensitively, after
+// skipping any leading whitespace, as the sniffing standard requires so
+// a blank line before "<html>" doesn't defeat detection. The tag must be
+// followed by whitespace or ">" to avoid matching e.g. "<body" inside
+// "<bodyartist>".
+func htmlSignature(tag string) func([]byte) bool {
+	t := []byte(tag)
+	return func(data []byte) bool {
+		data = skipWhitespace(data)
+		if len(data) < len(t)+1 {
+			return false
+		}
+		for i, want := range t {
+			if toLower(data[i]) != toLower(want) {
+				return false
+			}
+		}
+		switch data[len(t)] {
+		case ' ', '>', '\t', '\n', '\r', '\f':
+			return true
+		}
+		return false
+	}
+}
+
+// matchesMP4 implements the sniffing standard's MP4 signature: a box
+// whose declared size is a multiple of 4, at least 8 and no larger than
+// data, and whose type is "ftyp".
+func matchesMP4(data []byte) bool {
+	if len(data) < 8 {
+		return false
+	}
+	boxSize := int(data[0])<<24 | int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if boxSize < 8 || boxSize > len(data) || boxSize%4 != 0 {
+		return false
+	}
+	return string(data[4:8]) == "ftyp"
+}
+
+func skipWhitespace(data []byte) []byte {
+	for len(data) > 0 {
+		switch data[0] {
+		case '\t', '\n', '\x0c', '\r', ' ':
+			data = data[1:]
+			continue
+		}
+		break
+	}
+	return data
+}
+
+func toLower(b byte) byte {
+	if 'A' <= b && b <= 'Z' {
+		return b + ('a' - 'A')
+	}
+	return b
+}
+
+// looksTextual reports whether data contains no bytes the WHATWG
+// standard treats as "binary data octets" (control characters that
+// never appear in plain text).
+func looksTextual(data []byte) bool {
+	for _, b := range data {
+		switch {
+		case b <= 0x08, b == 0x0b, 0x0e <= b && b <= 0x1a, 0x1c <= b && b <= 0x1f:
+			return false
+		}
+	}
+	return true
+}
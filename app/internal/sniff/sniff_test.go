@@ -0,0 +1,70 @@
+package sniff
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", []byte{}, "text/plain; charset=utf-8"},
+		{"plain text", []byte("hello, world"), "text/plain; charset=utf-8"},
+		{"html doctype", []byte("<!DOCTYPE HTML><html></html>"), "text/html; charset=utf-8"},
+		{"html tag after whitespace", []byte("\n\t <HTML>"), "text/html; charset=utf-8"},
+		{"not html: tag prefix without boundary", []byte("<bodyartist>"), "text/plain; charset=utf-8"},
+		{"xml", []byte("<?xml version=\"1.0\"?>"), "text/xml; charset=utf-8"},
+		{"pdf", []byte("%PDF-1.4"), "application/pdf"},
+		{"utf-8 bom", []byte("\xef\xbb\xbfhello"), "text/plain; charset=utf-8"},
+		{"png", []byte("\x89PNG\r\n\x1a\nrest"), "image/png"},
+		{"gif87", []byte("GIF87a"), "image/gif"},
+		{"jpeg", []byte("\xff\xd8\xffrest"), "image/jpeg"},
+		{"zip", []byte("PK\x03\x04rest"), "application/zip"},
+		{"gzip", []byte("\x1f\x8b\x08rest"), "application/x-gzip"},
+		{
+			name: "wav: RIFF size field is masked out",
+			data: append([]byte("RIFF"), append([]byte{0x24, 0x00, 0x00, 0x00}, []byte("WAVEfmt ")...)...),
+			want: "audio/wave",
+		},
+		{
+			name: "webp: distinguished from wav by its container tag",
+			data: append([]byte("RIFF"), append([]byte{0x24, 0x00, 0x00, 0x00}, []byte("WEBPVP8 ")...)...),
+			want: "image/webp",
+		},
+		{
+			name: "mp4: ftyp box with a size that's a multiple of 4",
+			data: []byte{0x00, 0x00, 0x00, 0x0c, 'f', 't', 'y', 'p', 'i', 's', 'o', 'm'},
+			want: "video/mp4",
+		},
+		{
+			name: "mp4: rejected when declared size isn't a multiple of 4",
+			data: []byte{0x00, 0x00, 0x00, 0x09, 'f', 't', 'y', 'p', 'i'},
+			want: "application/octet-stream",
+		},
+		{
+			name: "binary control bytes are not textual",
+			data: []byte{0x00, 0x01, 0x02, 'x'},
+			want: "application/octet-stream",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DetectContentType(tc.data); got != tc.want {
+				t.Errorf("DetectContentType(%q) = %q, want %q", tc.data, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDetectContentTypeOnlyInspectsSniffLen(t *testing.T) {
+	// A binary byte just past sniffLen must not turn textual data into
+	// application/octet-stream.
+	data := append(bytes.Repeat([]byte("a"), sniffLen), 0x00)
+	if got := DetectContentType(data); got != "text/plain; charset=utf-8" {
+		t.Errorf("DetectContentType truncated to sniffLen = %q, want text/plain; charset=utf-8", got)
+	}
+}
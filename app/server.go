@@ -2,32 +2,49 @@ package main
 
 import (
 	"bufio"
-	"fmt"
 	"io"
 	"log"
 	"net"
+	"net/http"
 	"os"
-	"regexp"
 	"runtime"
-	"strconv"
 	"strings"
 	"sync"
-)
+	"time"
 
-type Headers struct {
-	Host           string
-	UserAgent      string
-	Accept         string
-	ContentLength  string
-	ContentType    string
-	AcceptEncoding string
-}
+	"github.com/rosnerdev/go-webserver/app/internal/accesslog"
+	"github.com/rosnerdev/go-webserver/app/internal/httpio"
+	"github.com/rosnerdev/go-webserver/app/internal/metrics"
+	"github.com/rosnerdev/go-webserver/app/internal/router"
+)
 
 const (
-	port         = ":4221"
-	maxReadBytes = 1024
+	port = ":4221"
+
+	// adminAddr serves expvar metrics, separately from the main listener
+	// so scraping it never competes with request traffic for a semaphore
+	// slot.
+	adminAddr = ":6060"
+
+	// idleTimeout bounds how long a persistent connection may sit between
+	// requests before handleConnection gives up its semaphore slot.
+	idleTimeout = 30 * time.Second
 )
 
+// logger is the access logger used by handleConnection and the handlers
+// in handlers.go. newLogger picks an implementation at startup; there's
+// no need for it to change afterward.
+var logger = newLogger()
+
+// newLogger returns a JSON logger if ACCESS_LOG_FORMAT=json is set in
+// the environment, and a Common Log Format logger otherwise.
+func newLogger() accesslog.Logger {
+	if strings.EqualFold(os.Getenv("ACCESS_LOG_FORMAT"), "json") {
+		return &accesslog.JSON{Out: os.Stdout}
+	}
+	return &accesslog.CommonLogFormat{Out: os.Stdout}
+}
+
 func main() {
 	maxWorkers := runtime.GOMAXPROCS(0)
 	listener, err := net.Listen("tcp", port)
@@ -37,7 +54,9 @@ func main() {
 	defer listener.Close()
 
 	log.Printf("Listening on %s", port)
+	go serveAdmin()
 
+	mux := newMux()
 	semaphore := make(chan struct{}, maxWorkers)
 	var wg sync.WaitGroup
 
@@ -48,206 +67,119 @@ func main() {
 			continue
 		}
 
+		metrics.QueuedConnections.Add(1)
 		semaphore <- struct{}{}
+		metrics.QueuedConnections.Add(-1)
+		metrics.ActiveConnections.Add(1)
 		wg.Add(1)
 
 		go func(c net.Conn) {
 			defer func() {
+				metrics.ActiveConnections.Add(-1)
 				wg.Done()
 				<-semaphore // Release the slot back to the semaphore
 			}()
-			handleConnection(c)
+			handleConnection(c, mux)
 		}(conn)
 	}
 }
 
-func handleConnection(conn net.Conn) {
+// serveAdmin runs expvar's handler (registered on http.DefaultServeMux
+// at "/debug/vars" by importing the metrics package) on its own
+// listener, so metrics.ActiveConnections/QueuedConnections can be
+// scraped independently of the main server.
+func serveAdmin() {
+	log.Printf("Admin metrics listening on %s", adminAddr)
+	if err := http.ListenAndServe(adminAddr, nil); err != nil {
+		log.Printf("Admin listener stopped: %v", err)
+	}
+}
+
+// handleConnection loops over the connection reading one HTTP/1.x request
+// at a time via httpio.ReadRequest, so a single keep-alive connection can
+// serve a pipeline of requests instead of pinning a semaphore slot for
+// just one. Each iteration resets the read deadline; an idle client that
+// never sends its next request eventually times out and the connection
+// closes instead of leaking the slot forever.
+func handleConnection(conn net.Conn, mux *router.Mux) {
 	defer conn.Close()
+	remoteAddr := conn.RemoteAddr().String()
 
 	reader := bufio.NewReader(conn)
-	requestLine, _, err := reader.ReadLine()
-	if err != nil {
-		log.Printf("Error reading connection: %v", err)
-		return
-	}
-
-	method := getMethod(string(requestLine))
-	path := getPath(string(requestLine))
-	headers, err := getHeaders(reader)
-	if err != nil {
-		log.Printf("Error reading headers: %v", err)
-		return
-	}
+	for {
+		conn.SetReadDeadline(time.Now().Add(idleTimeout))
 
-	var body []byte
-	if method == "POST" {
-		contentLength, _ := strconv.Atoi(headers.ContentLength)
-		body = make([]byte, contentLength)
-		_, err = io.ReadFull(reader, body)
+		req, err := httpio.ReadRequest(reader)
 		if err != nil {
-			log.Printf("Error reading request body: %v", err)
+			if err != io.EOF {
+				logger.Error(remoteAddr, err)
+			}
 			return
 		}
-	}
 
-	var statusCode, restResponse string
-	switch method {
-	case "POST":
-		statusCode, restResponse = postResponse(path, body)
-	case "GET":
-		statusCode, restResponse = getResponse(path, "", headers)
-	default:
-		statusCode, restResponse = "405 Method Not Allowed", "\r\n"
-	}
+		start := time.Now()
+		logger.RequestStarted(remoteAddr, req.Method, req.Path)
 
-	response := fmt.Sprintf("HTTP/1.1 %s\r\n%s", statusCode, restResponse)
-	_, err = conn.Write([]byte(response))
-	if err != nil {
-		log.Printf("Error writing response: %v", err)
-	}
-}
-
-func getPath(requestLine string) string {
-	parts := strings.Split(strings.TrimSpace(requestLine), " ")
-	if len(parts) < 2 {
-		return ""
-	}
-	return parts[1]
-}
-
-func getMethod(requestLine string) string {
-	parts := strings.Split(strings.TrimSpace(requestLine), " ")
-	if len(parts) == 0 {
-		return ""
-	}
-	return parts[0]
-}
-
-func getHeaders(reader *bufio.Reader) (Headers, error) {
-	headers := Headers{}
-	for {
-		line, err := reader.ReadString('\n')
+		// Drain the body regardless of method: leaving it unread would
+		// strand its bytes on the connection, and the next ReadRequest
+		// call on this same persistent connection would read straight
+		// into them instead of the next request line.
+		body, err := io.ReadAll(req.Body)
 		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return headers, fmt.Errorf("error reading header line: %w", err)
+			logger.Error(remoteAddr, err)
+			return
 		}
 
-		line = strings.TrimSpace(line)
-
-		if line == "" {
-			break
+		path, rawQuery := splitQuery(req.Path)
+		w := router.NewResponseWriter()
+		r := &router.Request{
+			Method:     req.Method,
+			Path:       path,
+			RawQuery:   rawQuery,
+			Header:     router.Header(req.Header),
+			Body:       body,
+			RemoteAddr: remoteAddr,
 		}
 
-		parts := strings.SplitN(line, ":", 2)
-		if len(parts) == 2 {
-			headerName := strings.TrimSpace(parts[0])
-			headerValue := strings.TrimSpace(parts[1])
-
-			switch strings.ToLower(headerName) {
-			case "host":
-				headers.Host = headerValue
-			case "user-agent":
-				headers.UserAgent = headerValue
-			case "accept":
-				headers.Accept = headerValue
-			case "content-length":
-				headers.ContentLength = headerValue
-			case "content-type":
-				headers.ContentType = headerValue
-			case "accept-encoding":
-				headers.AcceptEncoding = headerValue
-			}
+		switch req.Method {
+		case "GET", "POST":
+			mux.ServeHTTP(w, r)
+		default:
+			w.WriteHeader(router.StatusMethodNotAllowed)
 		}
-	}
-
-	return headers, nil
-}
-
-func postResponse(path string, body []byte) (string, string) {
-	switch {
-	case strings.HasPrefix(path, "/files"):
-		return handleFiles(path, "POST", body)
-	}
-	return "404 Not Found", "\r\n"
-}
-
-func getResponse(path, lastLine string, headers Headers) (string, string) {
-	switch {
-	case path == "/":
-		return "200 OK", "\r\n"
-	case strings.HasPrefix(path, "/echo"):
-		return handleEcho(path, headers)
-	case path == "/user-agent":
-		return handleUserAgent(headers)
-	case strings.HasPrefix(path, "/files"):
-		return handleFiles(path, "GET", []byte(lastLine))
-	}
-	return "404 Not Found", "\r\n"
-}
 
-func handleEcho(path string, headers Headers) (string, string) {
-	re := regexp.MustCompile(`^/echo(/(?P<toEcho>.*))?$`)
-	match := re.FindStringSubmatch(path)
-	if match != nil {
-		toEchoIndex := re.SubexpIndex("toEcho")
-		toEcho := match[toEchoIndex]
-		if toEcho == "" {
-			return "200 OK", "Content-Type: text/plain\r\nContent-Length: 0\r\n\r\n"
+		if req.Close {
+			w.Header().Set("Connection", "close")
 		}
 
-		if headers.AcceptEncoding == "gzip" {
-			return "200 OK", fmt.Sprintf("Content-Type: text/plain\r\nContent-Encoding: gzip\r\nContent-Length: %d\r\n\r\n%s", len(toEcho), toEcho)
+		rendered := router.Render(w)
+		if _, err := conn.Write(rendered); err != nil {
+			logger.Error(remoteAddr, err)
+			return
 		}
 
-		return "200 OK", fmt.Sprintf("Content-Type: text/plain\r\nContent-Length: %d\r\n\r\n%s", len(toEcho), toEcho)
+		logger.RequestCompleted(accesslog.Entry{
+			Timestamp:  start,
+			RemoteAddr: remoteAddr,
+			Method:     req.Method,
+			Path:       req.Path,
+			Proto:      req.Proto,
+			Status:     w.StatusCode(),
+			Bytes:      w.Len(),
+			Duration:   time.Since(start),
+		})
+
+		if req.Close {
+			return
+		}
 	}
-	return "404 Not Found", "\r\n"
 }
 
-func handleUserAgent(headers Headers) (string, string) {
-	return "200 OK", fmt.Sprintf("Content-Type: text/plain\r\nContent-Length: %d\r\n\r\n%s", len(headers.UserAgent), headers.UserAgent)
-}
-
-func handleFiles(path, method string, body []byte) (string, string) {
-	re := regexp.MustCompile(`^/files(/(?P<fileName>.*))?$`)
-	match := re.FindStringSubmatch(path)
-	if match != nil {
-		fileNameIndex := re.SubexpIndex("fileName")
-		fileName := match[fileNameIndex]
-		switch method {
-		case "GET":
-			if fileName == "" {
-				return "200 OK", "Content-Type: text/plain\r\nContent-Length: 0\r\n\r\n"
-			}
-
-			file, err := os.Open("/tmp/data/codecrafters.io/http-server-tester/" + fileName)
-			if err != nil {
-				log.Println(err)
-				return "404 Not Found", "\r\n"
-			}
-			defer file.Close()
-
-			var fileContent string
-			scanner := bufio.NewScanner(file)
-			for scanner.Scan() {
-				fileContent += scanner.Text()
-			}
-
-			return "200 OK", fmt.Sprintf("Content-Type: application/octet-stream\r\nContent-Length: %d\r\n\r\n%s", len(fileContent), fileContent)
-		case "POST":
-			if fileName == "" {
-				return "400 Bad Request", "\r\n"
-			}
-			filePath := "/tmp/data/codecrafters.io/http-server-tester/" + fileName
-			if err := os.WriteFile(filePath, body, 0644); err == nil {
-				return "201 Created", "\r\n"
-			} else {
-				log.Printf("Error writing file: %v", err)
-				return "500 Internal Server Error", "\r\n"
-			}
-		}
+// splitQuery separates a request target into its path and query string,
+// the same split net/http's URL parsing does on the "?".
+func splitQuery(target string) (path, rawQuery string) {
+	if i := strings.IndexByte(target, '?'); i != -1 {
+		return target[:i], target[i+1:]
 	}
-	return "404 Not Found", "\r\n"
+	return target, ""
 }